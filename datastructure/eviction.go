@@ -0,0 +1,223 @@
+package datastructures
+
+import (
+	"math/rand"
+	"time"
+)
+
+// EvictionPolicy selects which key StringStore evicts when a bounded store
+// is full. It mirrors Redis's maxmemory-policy options.
+type EvictionPolicy int
+
+const (
+	// NoEviction never evicts: once a limit is reached, new keys are still
+	// accepted and the store grows past the configured bound.
+	NoEviction EvictionPolicy = iota
+	// AllKeysLRU evicts the least-recently-used key among all keys.
+	AllKeysLRU
+	// AllKeysLFU evicts the least-frequently-used key among all keys.
+	AllKeysLFU
+	// VolatileLRU evicts the least-recently-used key among keys with a TTL.
+	VolatileLRU
+	// VolatileTTL evicts the key with a TTL closest to expiring.
+	VolatileTTL
+)
+
+// sampleSize is how many keys StringStore samples when picking an eviction
+// candidate, the same cheaper-than-strict-LRU approach Redis uses: a few
+// random keys approximate the true least-recently/frequently-used key
+// without the bookkeeping cost of a doubly-linked list.
+const sampleSize = 5
+
+// Approximated LFU counter tuning, following Redis's defaults: new keys
+// start at lfuInitVal, increments become less likely as the counter grows
+// (logCounterIncrement), and the counter decays with idle time.
+const (
+	lfuInitVal        = 5
+	lfuMaxCounter     = 255
+	lfuLogFactor      = 10.0
+	lfuDecayPerMinute = 1
+)
+
+// logCounterIncrement applies Redis's logarithmic counter increment: the
+// higher the counter, the less likely a single access bumps it further.
+func logCounterIncrement(counter uint8) uint8 {
+	if counter >= lfuMaxCounter {
+		return counter
+	}
+	base := float64(counter) - lfuInitVal
+	if base < 0 {
+		base = 0
+	}
+	probability := 1.0 / (base*lfuLogFactor + 1)
+	if rand.Float64() < probability {
+		return counter + 1
+	}
+	return counter
+}
+
+// effectiveFrequency returns counter decayed by lfuDecayPerMinute points
+// per minute of idle time, approximating Redis's idle-time decay
+// without a background sweep.
+func effectiveFrequency(counter uint8, idle time.Duration) uint8 {
+	decay := int(idle/time.Minute) * lfuDecayPerMinute
+	if decay <= 0 {
+		return counter
+	}
+	if decay >= int(counter) {
+		return 0
+	}
+	return counter - uint8(decay)
+}
+
+// evictedEntry is a key StringStore removed to stay within its configured
+// limits, along with the value it held at the time.
+type evictedEntry struct {
+	key   string
+	value string
+}
+
+// maybeEvictLocked evicts keys from sh under s.policy until inserting a new
+// key named candidateKey would no longer exceed the per-shard maxKeys or
+// maxBytes share. It must be called with sh.mu held and only when
+// candidateKey is not already present in sh (updates to an existing key
+// never evict). Evicted entries are returned so the caller can notify them
+// (via onEvict and Event) after releasing the lock.
+func (s *StringStore) maybeEvictLocked(sh *shard, candidateKey string, candidateValue string) []evictedEntry {
+	if s.policy == NoEviction || (s.maxKeys <= 0 && s.maxBytesPerShard <= 0) {
+		return nil
+	}
+
+	var evicted []evictedEntry
+	addedBytes := int64(len(candidateKey) + len(candidateValue))
+
+	for s.overLimitLocked(sh, addedBytes) {
+		victim, ok := s.pickVictimLocked(sh)
+		if ok {
+			evicted = append(evicted, evictedEntry{key: victim, value: sh.data[victim].value})
+			s.deleteLocked(sh, victim)
+			continue
+		}
+
+		// sh itself has nothing eligible to evict (it may simply hold very
+		// few keys right now): maxKeys is a global bound, so a key over it
+		// must come from wherever the keys actually are, not only from the
+		// shard being written to. maxBytes is enforced per shard, so other
+		// shards are only searched when the key limit is what's exceeded.
+		if s.maxKeys <= 0 || s.keyCount.Load()+1 <= int64(s.maxKeys) {
+			break
+		}
+		e, ok := s.evictFromOtherShardLocked(sh)
+		if !ok {
+			break // no eligible candidate anywhere; let the insert proceed
+		}
+		evicted = append(evicted, e)
+	}
+
+	return evicted
+}
+
+// evictFromOtherShardLocked looks for an eviction candidate in a shard other
+// than sh, which the caller already holds locked. It only considers shards
+// it can acquire without blocking (TryLock), so it never risks deadlocking
+// against another goroutine's Set/Increment on a different shard.
+func (s *StringStore) evictFromOtherShardLocked(sh *shard) (evictedEntry, bool) {
+	for _, other := range s.shards {
+		if other == sh || !other.mu.TryLock() {
+			continue
+		}
+
+		victim, ok := s.pickVictimLocked(other)
+		if !ok {
+			other.mu.Unlock()
+			continue
+		}
+
+		value := other.data[victim].value
+		s.deleteLocked(other, victim)
+		other.mu.Unlock()
+		return evictedEntry{key: victim, value: value}, true
+	}
+	return evictedEntry{}, false
+}
+
+func (s *StringStore) overLimitLocked(sh *shard, addedBytes int64) bool {
+	if s.maxKeys > 0 && s.keyCount.Load()+1 > int64(s.maxKeys) {
+		return true
+	}
+	if s.maxBytesPerShard > 0 && s.sizeBytesLocked(sh)+addedBytes > s.maxBytesPerShard {
+		return true
+	}
+	return false
+}
+
+func (s *StringStore) sizeBytesLocked(sh *shard) int64 {
+	var total int64
+	for key, entry := range sh.data {
+		total += int64(len(key) + len(entry.value))
+	}
+	return total
+}
+
+// pickVictimLocked samples sampleSize keys from sh and returns the best
+// eviction candidate for s.policy.
+func (s *StringStore) pickVictimLocked(sh *shard) (string, bool) {
+	now := time.Now()
+	var (
+		victim    string
+		found     bool
+		bestScore int64
+	)
+
+	sampled := 0
+	for key, entry := range sh.data {
+		if !eligibleForPolicy(s.policy, entry) {
+			continue
+		}
+
+		score := evictionScore(s.policy, entry, sh.meta[key], now)
+		if !found || score < bestScore {
+			victim, bestScore, found = key, score, true
+		}
+
+		sampled++
+		if sampled >= sampleSize {
+			break
+		}
+	}
+
+	return victim, found
+}
+
+// eligibleForPolicy reports whether entry can be evicted under policy.
+// The volatile-* policies only ever evict keys that have a TTL.
+func eligibleForPolicy(policy EvictionPolicy, entry stringEntry) bool {
+	switch policy {
+	case VolatileLRU, VolatileTTL:
+		return !entry.expiresAt.IsZero()
+	default:
+		return true
+	}
+}
+
+// evictionScore returns a value where lower means "evict first". It's kept
+// as an int64 rather than a float64: lastAccess.UnixNano() routinely exceeds
+// 2^53, the largest integer a float64 can represent exactly, which would
+// make near-simultaneous accesses compare as equal.
+func evictionScore(policy EvictionPolicy, entry stringEntry, meta *accessMeta, now time.Time) int64 {
+	var lastAccess time.Time
+	var frequency uint8
+	if meta != nil {
+		lastAccess = time.Unix(0, meta.lastAccess.Load())
+		frequency = uint8(meta.frequency.Load())
+	}
+
+	switch policy {
+	case AllKeysLFU:
+		return int64(effectiveFrequency(frequency, now.Sub(lastAccess)))
+	case VolatileTTL:
+		return int64(entry.expiresAt.Sub(now))
+	default: // AllKeysLRU, VolatileLRU
+		return lastAccess.UnixNano()
+	}
+}
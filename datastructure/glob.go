@@ -0,0 +1,75 @@
+package datastructures
+
+// matchGlob reports whether key matches a Redis-style glob pattern,
+// supporting '*', '?' and character classes like '[abc]' or '[^abc]'.
+func matchGlob(pattern, key string) bool {
+	if pattern == "" {
+		return key == ""
+	}
+
+	switch pattern[0] {
+	case '*':
+		if matchGlob(pattern[1:], key) {
+			return true
+		}
+		for i := 0; i < len(key); i++ {
+			if matchGlob(pattern[1:], key[i+1:]) {
+				return true
+			}
+		}
+		return false
+	case '?':
+		if len(key) == 0 {
+			return false
+		}
+		return matchGlob(pattern[1:], key[1:])
+	case '[':
+		if len(key) == 0 {
+			return false
+		}
+		end := indexByte(pattern, ']')
+		if end < 0 {
+			return key[0] == '[' && matchGlob(pattern[1:], key[1:])
+		}
+		class := pattern[1:end]
+		negate := false
+		if len(class) > 0 && class[0] == '^' {
+			negate = true
+			class = class[1:]
+		}
+		if classMatches(class, key[0]) != negate {
+			return matchGlob(pattern[end+1:], key[1:])
+		}
+		return false
+	default:
+		if len(key) == 0 || key[0] != pattern[0] {
+			return false
+		}
+		return matchGlob(pattern[1:], key[1:])
+	}
+}
+
+func classMatches(class string, b byte) bool {
+	for i := 0; i < len(class); i++ {
+		if i+2 < len(class) && class[i+1] == '-' {
+			if class[i] <= b && b <= class[i+2] {
+				return true
+			}
+			i += 2
+			continue
+		}
+		if class[i] == b {
+			return true
+		}
+	}
+	return false
+}
+
+func indexByte(s string, b byte) int {
+	for i := 0; i < len(s); i++ {
+		if s[i] == b {
+			return i
+		}
+	}
+	return -1
+}
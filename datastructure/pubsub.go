@@ -0,0 +1,138 @@
+package datastructures
+
+import (
+	"sync"
+	"time"
+)
+
+// EventOp identifies what kind of mutation produced an Event.
+type EventOp string
+
+const (
+	// OpSet fires whenever Set, Append, SetRange, or MSet stores a new or
+	// updated value.
+	OpSet EventOp = "SET"
+	// OpDel fires when Delete removes a key.
+	OpDel EventOp = "DEL"
+	// OpExpire fires when the background sweep removes a key whose TTL
+	// elapsed. A call that finds an expired key on its own (Set, Increment,
+	// Persist, Expire) drops it without publishing an event.
+	OpExpire EventOp = "EXPIRE"
+	// OpEvict fires when a key is removed to stay within a configured
+	// maxKeys/maxBytes limit.
+	OpEvict EventOp = "EVICT"
+	// OpIncr fires whenever Increment stores a new counter value.
+	OpIncr EventOp = "INCR"
+)
+
+// Event describes a single key mutation, delivered to subscribers whose
+// pattern matches Key.
+type Event struct {
+	Op       EventOp
+	Key      string
+	OldValue string
+	NewValue string
+	At       time.Time
+}
+
+// CancelFunc unsubscribes a Subscribe call, closing its event channel.
+type CancelFunc func()
+
+// eventBacklog is how many published events can queue before the fan-out
+// goroutine has drained them; publishing beyond this drops the event
+// rather than block the caller.
+const eventBacklog = 1024
+
+// subscriberBacklog is the per-subscriber channel size; a slow subscriber
+// misses events past this rather than stall fan-out to everyone else.
+const subscriberBacklog = 64
+
+type subscription struct {
+	pattern string
+	ch      chan Event
+
+	// mu guards closed so cancel's close(ch) and fanOut's send can never
+	// race: fanOut always checks closed under mu before sending, so a
+	// cancel that closes the channel mid-fanOut can't cause a send on a
+	// closed channel.
+	mu     sync.Mutex
+	closed bool
+}
+
+// startEventLoop wires up the buffered channel and fan-out goroutine that
+// back Subscribe. It must be called once by every StringStore constructor.
+func (s *StringStore) startEventLoop() {
+	s.events = make(chan Event, eventBacklog)
+	go s.fanOut()
+}
+
+// Subscribe returns a channel of Events whose Key matches pattern (Redis
+// glob syntax: '*', '?', '[abc]'), and a CancelFunc that unsubscribes and
+// closes the channel. The channel is buffered; a subscriber that falls
+// behind misses events rather than blocking other subscribers or the
+// store's write path.
+func (s *StringStore) Subscribe(pattern string) (<-chan Event, CancelFunc) {
+	sub := &subscription{
+		pattern: pattern,
+		ch:      make(chan Event, subscriberBacklog),
+	}
+
+	s.subMu.Lock()
+	s.subs = append(s.subs, sub)
+	s.subMu.Unlock()
+
+	cancel := func() {
+		s.subMu.Lock()
+		for i, existing := range s.subs {
+			if existing == sub {
+				s.subs = append(s.subs[:i], s.subs[i+1:]...)
+				break
+			}
+		}
+		s.subMu.Unlock()
+
+		sub.mu.Lock()
+		sub.closed = true
+		close(sub.ch)
+		sub.mu.Unlock()
+	}
+
+	return sub.ch, cancel
+}
+
+// publish enqueues event for fan-out. It never blocks: if the internal
+// backlog is full the event is dropped, trading delivery guarantees for
+// keeping the caller (which may be holding no lock, but is always on the
+// hot write path) fast.
+func (s *StringStore) publish(event Event) {
+	select {
+	case s.events <- event:
+	default:
+	}
+}
+
+// fanOut delivers queued events to matching subscribers. It runs for the
+// lifetime of the store and never takes a shard lock, so a slow or stuck
+// subscriber can never stall a Set/Get/Delete call.
+func (s *StringStore) fanOut() {
+	for event := range s.events {
+		s.subMu.Lock()
+		subs := make([]*subscription, len(s.subs))
+		copy(subs, s.subs)
+		s.subMu.Unlock()
+
+		for _, sub := range subs {
+			if !matchGlob(sub.pattern, event.Key) {
+				continue
+			}
+			sub.mu.Lock()
+			if !sub.closed {
+				select {
+				case sub.ch <- event:
+				default: // subscriber is behind; drop rather than block fan-out
+				}
+			}
+			sub.mu.Unlock()
+		}
+	}
+}
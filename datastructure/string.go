@@ -1,146 +1,895 @@
-package datastructures
-
-import (
-	"sync"
-	"time"
-	"fmt"
-)
-
-// StringStore -> string-specific operations
-type StringStore struct {
-	data     map[string]stringEntry
-	mu       sync.RWMutex
-}
-
-// stringEntry -> string value with metadata
-type stringEntry struct {
-	value     string
-	createdAt time.Time
-	expiresAt time.Time
-}
-
-// NewStringStore creates a new string store
-func NewStringStore() *StringStore {
-	store := &StringStore{
-		data: make(map[string]stringEntry),
-	}
-	
-	// Start background cleanup
-	go store.backgroundCleanup()
-	
-	return store
-}
-
-// Set adds or updates a string value with optional expiration
-func (s *StringStore) Set(key string, value string, expiration time.Duration) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	
-	entry := stringEntry{
-		value:     value,
-		createdAt: time.Now(),
-		expiresAt: time.Now().Add(expiration),
-	}
-	
-	s.data[key] = entry
-	return nil
-}
-
-// Get retrieves a string value
-func (s *StringStore) Get(key string) (string, bool) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	
-	entry, exists := s.data[key]
-	if !exists {
-		return "", false
-	}
-	
-	// Check for expiration
-	if time.Now().After(entry.expiresAt) {
-		return "", false
-	}
-	
-	return entry.value, true
-}
-
-// Delete removes a key
-func (s *StringStore) Delete(key string) bool {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	
-	_, exists := s.data[key]
-	if exists {
-		delete(s.data, key)
-		return true
-	}
-	return false
-}
-
-// Increment increments a numeric string value
-func (s *StringStore) Increment(key string, delta int) (int, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	
-	entry, exists := s.data[key]
-	if !exists {
-		// If key doesn't exist, start from 0
-		newValue := delta
-		s.data[key] = stringEntry{
-			value:     fmt.Sprintf("%d", newValue),
-			createdAt: time.Now(),
-			expiresAt: time.Time{}, // No expiration
-		}
-		return newValue, nil
-	}
-	
-	// Parse existing value
-	var currentValue int
-	_, err := fmt.Sscanf(entry.value, "%d", &currentValue)
-	if err != nil {
-		return 0, fmt.Errorf("cannot increment non-numeric value")
-	}
-	
-	// Calculate new value
-	newValue := currentValue + delta
-	
-	// Update store
-	s.data[key] = stringEntry{
-		value:     fmt.Sprintf("%d", newValue),
-		createdAt: entry.createdAt,
-		expiresAt: entry.expiresAt,
-	}
-	
-	return newValue, nil
-}
-
-// backgroundCleanup removes expired entries
-func (s *StringStore) backgroundCleanup() {
-	ticker := time.NewTicker(1 * time.Minute)
-	defer ticker.Stop()
-	
-	for range ticker.C {
-		s.mu.Lock()
-		now := time.Now()
-		
-		for key, entry := range s.data {
-			if !entry.expiresAt.IsZero() && now.After(entry.expiresAt) {
-				delete(s.data, key)
-			}
-		}
-		
-		s.mu.Unlock()
-	}
-}
-
-// Keys returns all current keys
-func (s *StringStore) Keys() []string {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	
-	keys := make([]string, 0, len(s.data))
-	for key := range s.data {
-		keys = append(keys, key)
-	}
-	return keys
-}
\ No newline at end of file
+package datastructures
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/avaniausekar/redis-toy-implementation/persistence"
+)
+
+// numShards is the fixed shard count backing StringStore. It must stay a
+// power of two: shard selection masks a key's hash instead of taking a
+// modulus.
+const numShards = 32
+
+// StringStore -> string-specific operations. Writes are spread across
+// numShards independently-locked shards (selected by hashing the key) so
+// that, for keys in different shards, one goroutine's Set doesn't block
+// another's Get.
+type StringStore struct {
+	shards    []*shard
+	shardMask uint32
+
+	backend persistence.Backend
+
+	// maxKeys bounds the total number of keys across all shards; keyCount
+	// is the live count maintained under each shard's lock as entries are
+	// inserted and removed, so the limit is one global bound rather than
+	// one divided across shards. Concurrent inserts into different shards,
+	// or an eviction that finds no lockable shard with a candidate, can
+	// still leave the count above maxKeys.
+	maxKeys  int
+	keyCount atomic.Int64
+
+	// maxBytesPerShard is maxBytes divided across shards; unlike maxKeys,
+	// bytes are still enforced per-shard, so the aggregate bound is
+	// approximate, particularly when maxBytes is small relative to
+	// numShards.
+	maxBytesPerShard int64
+	policy           EvictionPolicy
+
+	evictMu sync.RWMutex
+	onEvict func(key string)
+
+	events chan Event
+	subMu  sync.Mutex
+	subs   []*subscription
+}
+
+// shard is one independently-locked partition of the keyspace.
+type shard struct {
+	mu   sync.RWMutex
+	data map[string]stringEntry
+	meta map[string]*accessMeta
+}
+
+// stringEntry -> string value with metadata
+type stringEntry struct {
+	value     string
+	createdAt time.Time
+	expiresAt time.Time
+}
+
+// accessMeta backs the LRU/LFU eviction policies. It's stored as a pointer
+// alongside stringEntry, not inside it: Get only holds sh.mu's read lock, so
+// it updates lastAccess/frequency through these atomic fields instead of
+// writing sh.data, which would need the write lock and would serialize all
+// reads in the shard against each other.
+type accessMeta struct {
+	lastAccess atomic.Int64 // UnixNano
+	frequency  atomic.Uint32
+}
+
+func newAccessMeta(lastAccess time.Time, frequency uint32) *accessMeta {
+	m := &accessMeta{}
+	m.lastAccess.Store(lastAccess.UnixNano())
+	m.frequency.Store(frequency)
+	return m
+}
+
+func newShards() []*shard {
+	shards := make([]*shard, numShards)
+	for i := range shards {
+		shards[i] = &shard{data: make(map[string]stringEntry), meta: make(map[string]*accessMeta)}
+	}
+	return shards
+}
+
+// fnv32a is the 32-bit FNV-1a hash, used to pick a key's shard.
+func fnv32a(s string) uint32 {
+	const (
+		offset32 = 2166136261
+		prime32  = 16777619
+	)
+	hash := uint32(offset32)
+	for i := 0; i < len(s); i++ {
+		hash ^= uint32(s[i])
+		hash *= prime32
+	}
+	return hash
+}
+
+func (s *StringStore) shardFor(key string) *shard {
+	return s.shards[fnv32a(key)&s.shardMask]
+}
+
+// deleteLocked removes key from sh (both its value and its access metadata)
+// and keeps s.keyCount in sync. It must be called with sh.mu held, and only
+// when key is present in sh.data.
+func (s *StringStore) deleteLocked(sh *shard, key string) {
+	delete(sh.data, key)
+	delete(sh.meta, key)
+	s.keyCount.Add(-1)
+}
+
+func ceilDiv64(a int64, b int) int64 {
+	if a <= 0 || b <= 0 {
+		return 0
+	}
+	return (a + int64(b) - 1) / int64(b)
+}
+
+// NewStringStore creates a new string store
+func NewStringStore() *StringStore {
+	store := &StringStore{
+		shards:    newShards(),
+		shardMask: numShards - 1,
+	}
+
+	store.startEventLoop()
+	store.startBackgroundCleanup()
+
+	return store
+}
+
+// NewStringStoreWithLimits creates a bounded string store that evicts keys
+// under policy once it holds more than maxKeys keys or maxBytes of
+// key+value data. A limit of 0 means that dimension is unbounded; policy
+// NoEviction disables eviction entirely, so the store grows past either
+// limit instead.
+func NewStringStoreWithLimits(maxKeys int, maxBytes int64, policy EvictionPolicy) *StringStore {
+	store := &StringStore{
+		shards:           newShards(),
+		shardMask:        numShards - 1,
+		maxKeys:          maxKeys,
+		maxBytesPerShard: ceilDiv64(maxBytes, numShards),
+		policy:           policy,
+	}
+
+	store.startEventLoop()
+	store.startBackgroundCleanup()
+
+	return store
+}
+
+// OnEvict registers fn to be called, outside any internal lock, with the
+// key of every entry StringStore evicts to stay within its configured
+// limits. Passing nil disables notification.
+func (s *StringStore) OnEvict(fn func(key string)) {
+	s.evictMu.Lock()
+	defer s.evictMu.Unlock()
+	s.onEvict = fn
+}
+
+// notifyEvicted invokes the eviction callback and publishes an EVICT event
+// for each evicted entry; call it without holding any shard lock.
+func (s *StringStore) notifyEvicted(entries []evictedEntry) {
+	if len(entries) == 0 {
+		return
+	}
+	s.evictMu.RLock()
+	onEvict := s.onEvict
+	s.evictMu.RUnlock()
+
+	now := time.Now()
+	for _, e := range entries {
+		if onEvict != nil {
+			onEvict(e.key)
+		}
+		s.publish(Event{Op: OpEvict, Key: e.key, OldValue: e.value, At: now})
+	}
+}
+
+// NewStringStoreWithBackend creates a string store durable to backend: it
+// first restores the dataset backend last persisted, then logs every
+// mutating call to backend going forward. If snapshotInterval is positive,
+// a background goroutine periodically calls Snapshot to compact that log.
+func NewStringStoreWithBackend(backend persistence.Backend, snapshotInterval time.Duration) (*StringStore, error) {
+	entries, err := backend.Restore()
+	if err != nil {
+		return nil, fmt.Errorf("datastructures: restore from backend: %w", err)
+	}
+
+	store := &StringStore{
+		shards:    newShards(),
+		shardMask: numShards - 1,
+		backend:   backend,
+	}
+
+	now := time.Now()
+	var restored int64
+	for _, e := range entries {
+		if !e.ExpiresAt.IsZero() && now.After(e.ExpiresAt) {
+			continue
+		}
+		sh := store.shardFor(e.Key)
+		sh.data[e.Key] = stringEntry{value: e.Value, createdAt: e.CreatedAt, expiresAt: e.ExpiresAt}
+		sh.meta[e.Key] = newAccessMeta(time.Time{}, 0)
+		restored++
+	}
+	store.keyCount.Store(restored)
+
+	store.startEventLoop()
+	store.startBackgroundCleanup()
+	if snapshotInterval > 0 {
+		go store.backgroundSnapshot(snapshotInterval)
+	}
+
+	return store, nil
+}
+
+// Snapshot persists the current dataset to the backend, if one is
+// configured. For an AOF backend this is the BGREWRITEAOF equivalent:
+// the log is compacted down to one record per live key.
+func (s *StringStore) Snapshot() error {
+	if s.backend == nil {
+		return nil
+	}
+
+	var entries []persistence.Entry
+	for _, sh := range s.shards {
+		sh.mu.RLock()
+		for key, entry := range sh.data {
+			entries = append(entries, persistence.Entry{
+				Key:       key,
+				Value:     entry.value,
+				CreatedAt: entry.createdAt,
+				ExpiresAt: entry.expiresAt,
+			})
+		}
+		sh.mu.RUnlock()
+	}
+
+	return s.backend.Snapshot(entries)
+}
+
+// backgroundSnapshot periodically calls Snapshot to compact the backend
+func (s *StringStore) backgroundSnapshot(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := s.Snapshot(); err != nil {
+			log.Printf("datastructures: periodic snapshot failed: %v", err)
+		}
+	}
+}
+
+// logCommand appends a mutating command to the backend, if one is
+// configured, without holding any shard lock.
+func (s *StringStore) logCommand(args ...string) {
+	if s.backend == nil {
+		return
+	}
+	if err := s.backend.AppendCommand(args...); err != nil {
+		log.Printf("datastructures: failed to append to persistence backend: %v", err)
+	}
+}
+
+// SetOptions configures the expiration behavior of Set. The zero value
+// means "no expiration": Expiration == 0 and KeepTTL == false.
+type SetOptions struct {
+	// Expiration, if positive, sets a new TTL starting now. Zero means the
+	// key never expires. Ignored if KeepTTL is true.
+	Expiration time.Duration
+	// ExpireImmediately, set by a caller that resolved an absolute
+	// expiration (EXAT/PXAT) already in the past, stores the key already
+	// expired rather than with no TTL at all. Ignored if KeepTTL is true;
+	// takes priority over Expiration.
+	ExpireImmediately bool
+	// KeepTTL preserves the key's current expiration (if any) instead of
+	// applying Expiration.
+	KeepTTL bool
+	// NX only writes key if it does not already exist (or is present but
+	// expired). Mutually exclusive with XX.
+	NX bool
+	// XX only writes key if it already exists and is not expired. Mutually
+	// exclusive with NX.
+	XX bool
+}
+
+// Set adds or updates a string value, applying opts to decide whether the
+// key keeps its TTL, gets a new one, or never expires. The bool result
+// reports whether the value was written: it is always true unless NX or XX
+// was set and the key's existence didn't satisfy it, in which case Set
+// leaves the store untouched. Checking existence and writing happen under
+// the same shard lock, so concurrent NX/XX sets on the same key can't race.
+func (s *StringStore) Set(key string, value string, opts SetOptions) (bool, error) {
+	sh := s.shardFor(key)
+	sh.mu.Lock()
+
+	oldEntry, existed := sh.data[key]
+	if existed && !oldEntry.expiresAt.IsZero() && time.Now().After(oldEntry.expiresAt) {
+		s.deleteLocked(sh, key)
+		existed = false
+	}
+	if opts.NX && existed {
+		sh.mu.Unlock()
+		return false, nil
+	}
+	if opts.XX && !existed {
+		sh.mu.Unlock()
+		return false, nil
+	}
+
+	var evicted []evictedEntry
+	if !existed {
+		evicted = s.maybeEvictLocked(sh, key, value)
+		s.keyCount.Add(1)
+	}
+
+	var expiresAt time.Time
+	if opts.KeepTTL {
+		if existed {
+			expiresAt = oldEntry.expiresAt
+		}
+	} else if opts.ExpireImmediately {
+		expiresAt = time.Now().Add(-time.Second)
+	} else if opts.Expiration > 0 {
+		expiresAt = time.Now().Add(opts.Expiration)
+	}
+
+	now := time.Now()
+	sh.data[key] = stringEntry{
+		value:     value,
+		createdAt: now,
+		expiresAt: expiresAt,
+	}
+	sh.meta[key] = newAccessMeta(now, lfuInitVal)
+	sh.mu.Unlock()
+
+	s.notifyEvicted(evicted)
+
+	var oldValue string
+	if existed {
+		oldValue = oldEntry.value
+	}
+	s.publish(Event{Op: OpSet, Key: key, OldValue: oldValue, NewValue: value, At: now})
+
+	var expiresAtUnix int64
+	if !expiresAt.IsZero() {
+		expiresAtUnix = expiresAt.Unix()
+	}
+	s.logCommand("SET", key, value, strconv.FormatInt(expiresAtUnix, 10))
+	return true, nil
+}
+
+// Get retrieves a string value. It only takes sh.mu's read lock: the
+// lastAccess/frequency bump below goes through accessMeta's atomic fields
+// instead of rewriting sh.data, so concurrent Gets in the same shard don't
+// serialize against each other.
+func (s *StringStore) Get(key string) (string, bool) {
+	sh := s.shardFor(key)
+	sh.mu.RLock()
+	entry, exists := sh.data[key]
+	meta := sh.meta[key]
+	sh.mu.RUnlock()
+
+	if !exists {
+		return "", false
+	}
+
+	// A zero expiresAt means the key never expires
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		return "", false
+	}
+
+	if meta != nil {
+		meta.lastAccess.Store(time.Now().UnixNano())
+		meta.frequency.Store(uint32(logCounterIncrement(uint8(meta.frequency.Load()))))
+	}
+
+	return entry.value, true
+}
+
+// Persist removes the TTL from key, making it never expire. It returns
+// true if the key existed and had a TTL to remove.
+func (s *StringStore) Persist(key string) bool {
+	sh := s.shardFor(key)
+	sh.mu.Lock()
+
+	entry, exists := sh.data[key]
+	if !exists || entry.expiresAt.IsZero() {
+		sh.mu.Unlock()
+		return false
+	}
+	if time.Now().After(entry.expiresAt) {
+		s.deleteLocked(sh, key)
+		sh.mu.Unlock()
+		return false
+	}
+
+	entry.expiresAt = time.Time{}
+	sh.data[key] = entry
+	sh.mu.Unlock()
+
+	s.logCommand("PERSIST", key)
+	return true
+}
+
+// Expire sets key to expire after d. It returns true if the key exists.
+func (s *StringStore) Expire(key string, d time.Duration) bool {
+	at := time.Now().Add(d)
+	if !s.expireAt(key, at) {
+		return false
+	}
+	// Logged as an absolute EXPIREAT, not a relative EXPIRE: replaying the
+	// log later must reproduce the original deadline, not one measured
+	// from whenever restore happens to run.
+	s.logCommand("EXPIREAT", key, strconv.FormatInt(at.Unix(), 10))
+	return true
+}
+
+// ExpireAt sets key to expire at the absolute time t. If t is not in the
+// future the key is deleted immediately, matching Redis's behavior for an
+// expiration time in the past. It returns true if the key existed.
+func (s *StringStore) ExpireAt(key string, t time.Time) bool {
+	if !s.expireAt(key, t) {
+		return false
+	}
+	s.logCommand("EXPIREAT", key, strconv.FormatInt(t.Unix(), 10))
+	return true
+}
+
+// expireAt applies the expiration change under the key's shard lock;
+// callers log the command that produced it.
+func (s *StringStore) expireAt(key string, t time.Time) bool {
+	sh := s.shardFor(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	entry, exists := sh.data[key]
+	if !exists {
+		return false
+	}
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		s.deleteLocked(sh, key)
+		return false
+	}
+
+	if !t.After(time.Now()) {
+		s.deleteLocked(sh, key)
+		return true
+	}
+
+	entry.expiresAt = t
+	sh.data[key] = entry
+	return true
+}
+
+// TTL returns the remaining time-to-live for key. The bool result reports
+// whether the key exists; when it does and has no expiration, the returned
+// duration is -1, matching Redis's TTL command.
+func (s *StringStore) TTL(key string) (time.Duration, bool) {
+	sh := s.shardFor(key)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	entry, exists := sh.data[key]
+	if !exists {
+		return 0, false
+	}
+	if entry.expiresAt.IsZero() {
+		return -1, true
+	}
+
+	remaining := time.Until(entry.expiresAt)
+	if remaining <= 0 {
+		return 0, false
+	}
+	return remaining, true
+}
+
+// Delete removes a key
+func (s *StringStore) Delete(key string) bool {
+	sh := s.shardFor(key)
+	sh.mu.Lock()
+	entry, exists := sh.data[key]
+	if exists {
+		s.deleteLocked(sh, key)
+	}
+	sh.mu.Unlock()
+
+	if !exists {
+		return false
+	}
+	s.publish(Event{Op: OpDel, Key: key, OldValue: entry.value, At: time.Now()})
+	s.logCommand("DEL", key)
+	return true
+}
+
+// Increment increments a numeric string value
+func (s *StringStore) Increment(key string, delta int) (int, error) {
+	sh := s.shardFor(key)
+	sh.mu.Lock()
+
+	entry, exists := sh.data[key]
+	if exists && !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		s.deleteLocked(sh, key)
+		exists = false
+	}
+	if !exists {
+		// If key doesn't exist, start from 0, carrying no TTL
+		newValue := delta
+		newValueStr := fmt.Sprintf("%d", newValue)
+		evicted := s.maybeEvictLocked(sh, key, newValueStr)
+		s.keyCount.Add(1)
+
+		now := time.Now()
+		sh.data[key] = stringEntry{
+			value:     newValueStr,
+			createdAt: now,
+			expiresAt: time.Time{}, // No expiration
+		}
+		sh.meta[key] = newAccessMeta(now, lfuInitVal)
+		sh.mu.Unlock()
+
+		s.notifyEvicted(evicted)
+		s.publish(Event{Op: OpIncr, Key: key, NewValue: newValueStr, At: now})
+		s.logCommand("INCR", key, strconv.Itoa(newValue))
+		return newValue, nil
+	}
+
+	// Parse existing value
+	var currentValue int
+	_, err := fmt.Sscanf(entry.value, "%d", &currentValue)
+	if err != nil {
+		sh.mu.Unlock()
+		return 0, fmt.Errorf("cannot increment non-numeric value")
+	}
+
+	// Calculate new value
+	newValue := currentValue + delta
+	oldValue := entry.value
+
+	// Update store
+	entry.value = fmt.Sprintf("%d", newValue)
+	sh.data[key] = entry
+	now := time.Now()
+	if meta := sh.meta[key]; meta != nil {
+		meta.lastAccess.Store(now.UnixNano())
+		meta.frequency.Store(uint32(logCounterIncrement(uint8(meta.frequency.Load()))))
+	}
+	sh.mu.Unlock()
+
+	s.publish(Event{Op: OpIncr, Key: key, OldValue: oldValue, NewValue: entry.value, At: now})
+	s.logCommand("INCR", key, strconv.Itoa(newValue))
+	return newValue, nil
+}
+
+// startBackgroundCleanup launches one expiration sweep goroutine per shard,
+// so each only ever walks its own data.
+func (s *StringStore) startBackgroundCleanup() {
+	for _, sh := range s.shards {
+		go s.cleanupShard(sh)
+	}
+}
+
+// cleanupShard periodically removes expired entries from sh
+func (s *StringStore) cleanupShard(sh *shard) {
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		sh.mu.Lock()
+		now := time.Now()
+
+		var expired []evictedEntry
+		for key, entry := range sh.data {
+			if !entry.expiresAt.IsZero() && now.After(entry.expiresAt) {
+				expired = append(expired, evictedEntry{key: key, value: entry.value})
+				s.deleteLocked(sh, key)
+			}
+		}
+
+		sh.mu.Unlock()
+
+		for _, e := range expired {
+			s.publish(Event{Op: OpExpire, Key: e.key, OldValue: e.value, At: now})
+		}
+	}
+}
+
+// Keys returns all current keys, walking shards in a stable (index) order.
+func (s *StringStore) Keys() []string {
+	var keys []string
+	for _, sh := range s.shards {
+		sh.mu.RLock()
+		for key := range sh.data {
+			keys = append(keys, key)
+		}
+		sh.mu.RUnlock()
+	}
+	return keys
+}
+
+// Exists returns how many of the given keys are present and unexpired
+func (s *StringStore) Exists(keys ...string) int {
+	now := time.Now()
+	count := 0
+	for _, key := range keys {
+		sh := s.shardFor(key)
+		sh.mu.RLock()
+		entry, ok := sh.data[key]
+		if ok && (entry.expiresAt.IsZero() || now.Before(entry.expiresAt)) {
+			count++
+		}
+		sh.mu.RUnlock()
+	}
+	return count
+}
+
+// Append appends value to the existing string at key, creating it if absent,
+// and returns the length of the string after the append
+func (s *StringStore) Append(key string, value string) (int, error) {
+	sh := s.shardFor(key)
+	sh.mu.Lock()
+
+	entry, presentInMap := sh.data[key]
+	exists := presentInMap
+	if exists && !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		s.deleteLocked(sh, key)
+		exists = false
+		presentInMap = false
+	}
+	var oldValue string
+	if exists {
+		oldValue = entry.value
+	}
+	var evicted []evictedEntry
+	if !exists {
+		evicted = s.maybeEvictLocked(sh, key, value)
+		entry = stringEntry{createdAt: time.Now()}
+		sh.meta[key] = newAccessMeta(time.Now(), lfuInitVal)
+	}
+
+	entry.value += value
+	sh.data[key] = entry
+	length := len(entry.value)
+	if !presentInMap {
+		s.keyCount.Add(1)
+	}
+	sh.mu.Unlock()
+
+	s.notifyEvicted(evicted)
+	s.publish(Event{Op: OpSet, Key: key, OldValue: oldValue, NewValue: entry.value, At: time.Now()})
+	s.logCommand("APPEND", key, value)
+	return length, nil
+}
+
+// Strlen returns the length of the string at key, or 0 if it does not exist
+func (s *StringStore) Strlen(key string) int {
+	sh := s.shardFor(key)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	entry, exists := sh.data[key]
+	if !exists {
+		return 0
+	}
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		return 0
+	}
+	return len(entry.value)
+}
+
+// GetRange returns the substring of the string at key between start and end,
+// inclusive, following Redis semantics for negative and out-of-range indices
+func (s *StringStore) GetRange(key string, start, end int) string {
+	sh := s.shardFor(key)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	entry, exists := sh.data[key]
+	if !exists {
+		return ""
+	}
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		return ""
+	}
+
+	value := entry.value
+	length := len(value)
+	if length == 0 {
+		return ""
+	}
+
+	if start < 0 {
+		start = length + start
+	}
+	if end < 0 {
+		end = length + end
+	}
+	if start < 0 {
+		start = 0
+	}
+	if end >= length {
+		end = length - 1
+	}
+	if start > end || start >= length {
+		return ""
+	}
+
+	return value[start : end+1]
+}
+
+// SetRange overwrites part of the string at key starting at offset, padding
+// with zero bytes if offset is beyond the current length, and returns the
+// length of the string after the operation
+func (s *StringStore) SetRange(key string, offset int, value string) (int, error) {
+	if offset < 0 {
+		return 0, fmt.Errorf("offset out of range")
+	}
+
+	sh := s.shardFor(key)
+	sh.mu.Lock()
+
+	entry, presentInMap := sh.data[key]
+	exists := presentInMap
+	if exists && !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		s.deleteLocked(sh, key)
+		exists = false
+		presentInMap = false
+	}
+	if len(value) == 0 && !exists {
+		// Nothing to write and nothing to pad: a missing key stays missing.
+		sh.mu.Unlock()
+		return 0, nil
+	}
+	var oldValue string
+	if exists {
+		oldValue = entry.value
+	}
+	var evicted []evictedEntry
+	if !exists {
+		evicted = s.maybeEvictLocked(sh, key, value)
+		entry = stringEntry{createdAt: time.Now()}
+		sh.meta[key] = newAccessMeta(time.Now(), lfuInitVal)
+	}
+
+	if len(value) == 0 {
+		sh.data[key] = entry
+		length := len(entry.value)
+		if !presentInMap {
+			s.keyCount.Add(1)
+		}
+		sh.mu.Unlock()
+		s.notifyEvicted(evicted)
+		return length, nil
+	}
+
+	padded := []byte(entry.value)
+	needed := offset + len(value)
+	if len(padded) < needed {
+		grown := make([]byte, needed)
+		copy(grown, padded)
+		padded = grown
+	}
+	copy(padded[offset:], value)
+
+	entry.value = string(padded)
+	sh.data[key] = entry
+	length := len(entry.value)
+	if !presentInMap {
+		s.keyCount.Add(1)
+	}
+	sh.mu.Unlock()
+
+	s.notifyEvicted(evicted)
+	s.publish(Event{Op: OpSet, Key: key, OldValue: oldValue, NewValue: entry.value, At: time.Now()})
+	s.logCommand("SETRANGE", key, strconv.Itoa(offset), value)
+	return length, nil
+}
+
+// MGet retrieves multiple string values at once, returning nil for any key
+// that does not exist or has expired. Each key is looked up under its own
+// shard's lock, not one lock across the whole call.
+func (s *StringStore) MGet(keys ...string) []*string {
+	now := time.Now()
+	results := make([]*string, len(keys))
+	for i, key := range keys {
+		sh := s.shardFor(key)
+		sh.mu.RLock()
+		entry, exists := sh.data[key]
+		sh.mu.RUnlock()
+
+		if !exists {
+			continue
+		}
+		if !entry.expiresAt.IsZero() && now.After(entry.expiresAt) {
+			continue
+		}
+		value := entry.value
+		results[i] = &value
+	}
+	return results
+}
+
+// MSet sets multiple key-value pairs at once; pairs must have an even length
+// alternating key, value, key, value, ... Each pair is written under its own
+// shard's lock, so MSet is not atomic across keys in different shards.
+func (s *StringStore) MSet(pairs ...string) error {
+	if len(pairs)%2 != 0 {
+		return fmt.Errorf("MSET requires an even number of arguments")
+	}
+
+	now := time.Now()
+	for i := 0; i < len(pairs); i += 2 {
+		key, value := pairs[i], pairs[i+1]
+		sh := s.shardFor(key)
+		sh.mu.Lock()
+		oldEntry, presentInMap := sh.data[key]
+		var evicted []evictedEntry
+		if !presentInMap {
+			evicted = s.maybeEvictLocked(sh, key, value)
+			s.keyCount.Add(1)
+		}
+		sh.data[key] = stringEntry{value: value, createdAt: now}
+		sh.meta[key] = newAccessMeta(now, lfuInitVal)
+		sh.mu.Unlock()
+
+		s.notifyEvicted(evicted)
+		var oldValue string
+		if presentInMap {
+			oldValue = oldEntry.value
+		}
+		s.publish(Event{Op: OpSet, Key: key, OldValue: oldValue, NewValue: value, At: now})
+		s.logCommand("SET", key, value, "0")
+	}
+	return nil
+}
+
+// scanCount is the default number of keys Scan returns per call when count
+// is not positive.
+const scanCount = 10
+
+// Scan walks the keyspace a shard at a time, returning up to count keys and
+// a cursor to pass to the next call. A cursor of 0 starts the scan; Scan
+// returns a cursor of 0 once the scan is complete. Scan makes no isolation
+// guarantees across calls: like Redis's own SCAN, it may return a key
+// multiple times or miss a key modified concurrently with the scan.
+//
+// The cursor encodes (shardIndex, position) rather than a true bucket
+// index: Go's map type doesn't expose bucket iteration, so each shard's
+// keys are sorted at scan time to give a stable, resumable order instead.
+func (s *StringStore) Scan(cursor uint64, count int) ([]string, uint64) {
+	if count <= 0 {
+		count = scanCount
+	}
+
+	shardIndex := uint32(cursor >> 32)
+	position := int(uint32(cursor))
+
+	var keys []string
+	for int(shardIndex) < len(s.shards) && len(keys) < count {
+		sh := s.shards[shardIndex]
+
+		sh.mu.RLock()
+		shardKeys := make([]string, 0, len(sh.data))
+		for key := range sh.data {
+			shardKeys = append(shardKeys, key)
+		}
+		sh.mu.RUnlock()
+		sort.Strings(shardKeys)
+
+		for position < len(shardKeys) && len(keys) < count {
+			keys = append(keys, shardKeys[position])
+			position++
+		}
+
+		if position >= len(shardKeys) {
+			shardIndex++
+			position = 0
+		}
+	}
+
+	if int(shardIndex) >= len(s.shards) {
+		return keys, 0
+	}
+	return keys, uint64(shardIndex)<<32 | uint64(position)
+}
@@ -0,0 +1,227 @@
+package datastructures
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestExpirePersistTTL(t *testing.T) {
+	s := NewStringStore()
+
+	if _, err := s.Set("k", "v", SetOptions{}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if remaining, exists := s.TTL("k"); !exists || remaining != -1 {
+		t.Fatalf("expected TTL -1 for a key with no expiration, got %v, %v", remaining, exists)
+	}
+
+	if !s.Expire("k", time.Hour) {
+		t.Fatalf("Expire on an existing key should return true")
+	}
+	if remaining, exists := s.TTL("k"); !exists || remaining <= 0 {
+		t.Fatalf("expected a positive TTL after Expire, got %v, %v", remaining, exists)
+	}
+
+	if !s.Persist("k") {
+		t.Fatalf("Persist should report true when it removes a TTL")
+	}
+	if remaining, exists := s.TTL("k"); !exists || remaining != -1 {
+		t.Fatalf("expected TTL -1 after Persist, got %v, %v", remaining, exists)
+	}
+}
+
+// TestSetOverExpiredKeyDoesNotLeakKeyCount guards the common TTL-expire-then
+// -reset cache pattern: re-SETting a key that's still physically present but
+// lazily expired must not double-count it against maxKeys.
+func TestSetOverExpiredKeyDoesNotLeakKeyCount(t *testing.T) {
+	s := NewStringStoreWithLimits(100, 0, NoEviction)
+
+	if _, err := s.Set("k", "v1", SetOptions{Expiration: time.Millisecond}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	if _, err := s.Set("k", "v2", SetOptions{}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	if got := s.keyCount.Load(); got != 1 {
+		t.Fatalf("expected keyCount 1 after re-setting an expired key, got %d", got)
+	}
+}
+
+// TestKeepTTLOnExpiredKeyDoesNotResurrectTTL guards Redis's "an expired key
+// is absent" rule: KEEPTTL over a lazily-expired key must leave no TTL, not
+// carry forward the stale (already-past) deadline.
+func TestKeepTTLOnExpiredKeyDoesNotResurrectTTL(t *testing.T) {
+	s := NewStringStore()
+
+	if _, err := s.Set("k", "v1", SetOptions{Expiration: time.Millisecond}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	if _, err := s.Set("k", "v2", SetOptions{KeepTTL: true}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	remaining, exists := s.TTL("k")
+	if !exists {
+		t.Fatalf("expected key to exist after re-set")
+	}
+	if remaining != -1 {
+		t.Fatalf("expected KeepTTL on a previously-expired key to leave no TTL, got remaining=%v", remaining)
+	}
+}
+
+func TestGetLazilyExpiresKey(t *testing.T) {
+	s := NewStringStore()
+
+	if _, err := s.Set("k", "v", SetOptions{Expiration: time.Millisecond}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	if _, ok := s.Get("k"); ok {
+		t.Fatalf("expected Get to treat an expired key as absent")
+	}
+	if _, exists := s.TTL("k"); exists {
+		t.Fatalf("expected TTL to report an expired key as absent")
+	}
+}
+
+// TestSetExatPastIsImmediatelyExpired guards a resolved EXAT/PXAT in the past:
+// the key must be stored already-expired, not with no expiration at all.
+func TestSetExatPastIsImmediatelyExpired(t *testing.T) {
+	s := NewStringStore()
+
+	ok, err := s.Set("k", "v", SetOptions{ExpireImmediately: true})
+	if err != nil || !ok {
+		t.Fatalf("Set: ok=%v err=%v", ok, err)
+	}
+	if _, exists := s.Get("k"); exists {
+		t.Fatalf("expected a key set with ExpireImmediately to read back as absent")
+	}
+}
+
+// TestAppendSetRangeMSetRespectMaxKeys guards maxKeys as a global bound:
+// these paths create new keys too and must evict just like Set.
+func TestAppendSetRangeMSetRespectMaxKeys(t *testing.T) {
+	s := NewStringStoreWithLimits(2, 0, AllKeysLRU)
+
+	if _, err := s.Append("a", "1"); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if _, err := s.SetRange("b", 0, "2"); err != nil {
+		t.Fatalf("SetRange: %v", err)
+	}
+	if err := s.MSet("c", "3"); err != nil {
+		t.Fatalf("MSet: %v", err)
+	}
+
+	if got := len(s.Keys()); got > 2 {
+		t.Fatalf("expected at most 2 keys under maxKeys=2, got %d", got)
+	}
+}
+
+// TestAppendSetRangeMSetPublishEvents guards a change-watcher actually
+// seeing value mutations made through APPEND/SETRANGE/MSET, not just SET.
+func TestAppendSetRangeMSetPublishEvents(t *testing.T) {
+	s := NewStringStore()
+	events, cancel := s.Subscribe("*")
+	defer cancel()
+
+	if _, err := s.Append("a", "1"); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if _, err := s.SetRange("b", 0, "2"); err != nil {
+		t.Fatalf("SetRange: %v", err)
+	}
+	if err := s.MSet("c", "3"); err != nil {
+		t.Fatalf("MSet: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	for i := 0; i < 3; i++ {
+		select {
+		case e := <-events:
+			if e.Op != OpSet {
+				t.Fatalf("expected OpSet, got %v", e.Op)
+			}
+			seen[e.Key] = true
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for event %d", i)
+		}
+	}
+	for _, key := range []string{"a", "b", "c"} {
+		if !seen[key] {
+			t.Fatalf("expected an event for key %q", key)
+		}
+	}
+}
+
+func TestAppendSkipsExpiredValue(t *testing.T) {
+	s := NewStringStore()
+
+	if _, err := s.Set("k", "stale", SetOptions{Expiration: time.Millisecond}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	n, err := s.Append("k", "fresh")
+	if err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if n != len("fresh") {
+		t.Fatalf("expected Append to start over on an expired key, got length %d", n)
+	}
+	if v, _ := s.Get("k"); v != "fresh" {
+		t.Fatalf("expected value %q, got %q", "fresh", v)
+	}
+}
+
+func TestSetNXAtomicUnderConcurrency(t *testing.T) {
+	s := NewStringStore()
+
+	const n = 50
+	var wg sync.WaitGroup
+	successes := make([]bool, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ok, err := s.Set("k", "v", SetOptions{NX: true})
+			if err != nil {
+				t.Errorf("Set: %v", err)
+			}
+			successes[i] = ok
+		}(i)
+	}
+	wg.Wait()
+
+	count := 0
+	for _, ok := range successes {
+		if ok {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Fatalf("expected exactly one concurrent SET NX to succeed, got %d", count)
+	}
+}
+
+func TestSetXXRequiresExistingKey(t *testing.T) {
+	s := NewStringStore()
+
+	ok, err := s.Set("k", "v", SetOptions{XX: true})
+	if err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if ok {
+		t.Fatalf("expected SET XX on a missing key to report false")
+	}
+	if _, exists := s.Get("k"); exists {
+		t.Fatalf("expected SET XX on a missing key to leave the store untouched")
+	}
+}
@@ -0,0 +1,309 @@
+package persistence
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SyncPolicy controls how often the AOF is fsynced to disk.
+type SyncPolicy int
+
+const (
+	// SyncAlways fsyncs after every AppendCommand
+	SyncAlways SyncPolicy = iota
+	// SyncEverysec fsyncs once a second in the background
+	SyncEverysec
+	// SyncNo leaves fsyncing to the operating system
+	SyncNo
+)
+
+// AOFBackend is an append-only write-ahead log: every mutating command is
+// written as a RESP-encoded array before it's considered durable. Snapshot
+// acts as the BGREWRITEAOF equivalent: it writes the given entries as a
+// fresh, compacted command log and atomically swaps it in for the current
+// one.
+type AOFBackend struct {
+	path   string
+	policy SyncPolicy
+
+	mu     sync.Mutex
+	file   *os.File
+	writer *bufio.Writer
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// OpenAOF opens (creating if necessary) the AOF file at path and, for
+// SyncEverysec, starts the background fsync loop.
+func OpenAOF(path string, policy SyncPolicy) (*AOFBackend, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("persistence: open AOF: %w", err)
+	}
+
+	a := &AOFBackend{
+		path:   path,
+		policy: policy,
+		file:   file,
+		writer: bufio.NewWriter(file),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	if policy == SyncEverysec {
+		go a.syncLoop()
+	} else {
+		close(a.done)
+	}
+
+	return a, nil
+}
+
+func (a *AOFBackend) syncLoop() {
+	defer close(a.done)
+
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			a.mu.Lock()
+			a.flushAndSync()
+			a.mu.Unlock()
+		case <-a.stop:
+			return
+		}
+	}
+}
+
+// flushAndSync must be called with a.mu held
+func (a *AOFBackend) flushAndSync() error {
+	if err := a.writer.Flush(); err != nil {
+		return err
+	}
+	return a.file.Sync()
+}
+
+// AppendCommand logs one mutating command to the AOF
+func (a *AOFBackend) AppendCommand(args ...string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, err := a.writer.Write(encodeCommand(args...)); err != nil {
+		return fmt.Errorf("persistence: append AOF record: %w", err)
+	}
+
+	if a.policy == SyncAlways {
+		return a.flushAndSync()
+	}
+	return a.writer.Flush()
+}
+
+// Snapshot rewrites the AOF from scratch using entries as the source of
+// truth, then atomically replaces the current file. This is the
+// BGREWRITEAOF equivalent: it compacts away the command history in favor
+// of one SET per key, carrying the key's expiration (if any) as an
+// absolute Unix time.
+func (a *AOFBackend) Snapshot(entries []Entry) error {
+	tmpPath := a.path + ".rewrite"
+	tmpFile, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("persistence: create AOF rewrite file: %w", err)
+	}
+
+	w := bufio.NewWriter(tmpFile)
+	for _, e := range entries {
+		var expiresAtUnix int64
+		if !e.ExpiresAt.IsZero() {
+			expiresAtUnix = e.ExpiresAt.Unix()
+		}
+		record := encodeCommand("SET", e.Key, e.Value, strconv.FormatInt(expiresAtUnix, 10))
+		if _, err := w.Write(record); err != nil {
+			tmpFile.Close()
+			return fmt.Errorf("persistence: write AOF rewrite record: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("persistence: flush AOF rewrite file: %w", err)
+	}
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("persistence: sync AOF rewrite file: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("persistence: close AOF rewrite file: %w", err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := a.flushAndSync(); err != nil {
+		return err
+	}
+	if err := a.file.Close(); err != nil {
+		return fmt.Errorf("persistence: close current AOF: %w", err)
+	}
+	if err := os.Rename(tmpPath, a.path); err != nil {
+		return fmt.Errorf("persistence: swap in rewritten AOF: %w", err)
+	}
+
+	file, err := os.OpenFile(a.path, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return fmt.Errorf("persistence: reopen AOF after rewrite: %w", err)
+	}
+	a.file = file
+	a.writer = bufio.NewWriter(file)
+	return nil
+}
+
+// Restore replays the AOF from the beginning and returns the resulting
+// dataset.
+func (a *AOFBackend) Restore() ([]Entry, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := a.flushAndSync(); err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(a.path)
+	if err != nil {
+		return nil, fmt.Errorf("persistence: open AOF for replay: %w", err)
+	}
+	defer file.Close()
+
+	data := make(map[string]Entry)
+	r := bufio.NewReader(file)
+	for {
+		args, err := decodeCommand(r)
+		if err != nil {
+			break // EOF or trailing partial record; stop replay here
+		}
+		applyCommand(data, args)
+	}
+
+	entries := make([]Entry, 0, len(data))
+	for _, e := range data {
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// applyCommand replays a single logged command against the scratch dataset
+// used by Restore.
+func applyCommand(data map[string]Entry, args []string) {
+	if len(args) == 0 {
+		return
+	}
+
+	switch args[0] {
+	case "SET":
+		if len(args) != 4 {
+			return
+		}
+		expiresAtUnix, err := strconv.ParseInt(args[3], 10, 64)
+		if err != nil {
+			return
+		}
+		entry := Entry{Key: args[1], Value: args[2], CreatedAt: time.Now()}
+		if expiresAtUnix != 0 {
+			entry.ExpiresAt = time.Unix(expiresAtUnix, 0)
+		}
+		data[args[1]] = entry
+	case "DEL":
+		for _, key := range args[1:] {
+			delete(data, key)
+		}
+	case "INCR":
+		if len(args) != 3 {
+			return
+		}
+		entry := data[args[1]]
+		entry.Key = args[1]
+		entry.Value = args[2]
+		data[args[1]] = entry
+	case "EXPIRE":
+		if len(args) != 3 {
+			return
+		}
+		seconds, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			return
+		}
+		if entry, ok := data[args[1]]; ok {
+			entry.ExpiresAt = time.Now().Add(time.Duration(seconds) * time.Second)
+			data[args[1]] = entry
+		}
+	case "EXPIREAT":
+		if len(args) != 3 {
+			return
+		}
+		unixSeconds, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			return
+		}
+		if entry, ok := data[args[1]]; ok {
+			entry.ExpiresAt = time.Unix(unixSeconds, 0)
+			data[args[1]] = entry
+		}
+	case "PERSIST":
+		if len(args) != 2 {
+			return
+		}
+		if entry, ok := data[args[1]]; ok {
+			entry.ExpiresAt = time.Time{}
+			data[args[1]] = entry
+		}
+	case "APPEND":
+		if len(args) != 3 {
+			return
+		}
+		entry := data[args[1]]
+		entry.Key = args[1]
+		entry.Value += args[2]
+		data[args[1]] = entry
+	case "SETRANGE":
+		if len(args) != 4 {
+			return
+		}
+		offset, err := strconv.Atoi(args[2])
+		if err != nil || offset < 0 {
+			return
+		}
+		entry := data[args[1]]
+		entry.Key = args[1]
+		if add := args[3]; add != "" {
+			padded := []byte(entry.Value)
+			needed := offset + len(add)
+			if len(padded) < needed {
+				grown := make([]byte, needed)
+				copy(grown, padded)
+				padded = grown
+			}
+			copy(padded[offset:], add)
+			entry.Value = string(padded)
+		}
+		data[args[1]] = entry
+	}
+}
+
+// Close flushes and closes the AOF file
+func (a *AOFBackend) Close() error {
+	close(a.stop)
+	<-a.done
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := a.flushAndSync(); err != nil {
+		return err
+	}
+	return a.file.Close()
+}
@@ -0,0 +1,76 @@
+package persistence
+
+import (
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func openTestAOF(t *testing.T) *AOFBackend {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "appendonly.aof")
+	a, err := OpenAOF(path, SyncAlways)
+	if err != nil {
+		t.Fatalf("OpenAOF: %v", err)
+	}
+	t.Cleanup(func() { a.Close() })
+	return a
+}
+
+func TestAOFRestoreRoundTrip(t *testing.T) {
+	a := openTestAOF(t)
+
+	if err := a.AppendCommand("SET", "a", "1", "0"); err != nil {
+		t.Fatalf("AppendCommand SET: %v", err)
+	}
+	if err := a.AppendCommand("SET", "b", "2", "0"); err != nil {
+		t.Fatalf("AppendCommand SET: %v", err)
+	}
+	if err := a.AppendCommand("DEL", "b"); err != nil {
+		t.Fatalf("AppendCommand DEL: %v", err)
+	}
+	if err := a.AppendCommand("INCR", "a", "2"); err != nil {
+		t.Fatalf("AppendCommand INCR: %v", err)
+	}
+
+	entries, err := a.Restore()
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 surviving entry, got %d: %+v", len(entries), entries)
+	}
+	if entries[0].Key != "a" || entries[0].Value != "2" {
+		t.Fatalf("expected a=2, got %+v", entries[0])
+	}
+}
+
+// TestAOFExpireLoggedAbsolute checks that an EXPIREAT record, the form
+// StringStore logs expirations in, replays to its original absolute
+// deadline, not one measured from whenever Restore happens to run.
+func TestAOFExpireLoggedAbsolute(t *testing.T) {
+	a := openTestAOF(t)
+
+	if err := a.AppendCommand("SET", "k", "v", "0"); err != nil {
+		t.Fatalf("AppendCommand SET: %v", err)
+	}
+
+	// A deadline already in the past: if EXPIRE were replayed relative to
+	// restore time, this key would wrongly survive.
+	past := time.Now().Add(-time.Hour).Unix()
+	if err := a.AppendCommand("EXPIREAT", "k", strconv.FormatInt(past, 10)); err != nil {
+		t.Fatalf("AppendCommand EXPIREAT: %v", err)
+	}
+
+	entries, err := a.Restore()
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+
+	for _, e := range entries {
+		if e.Key == "k" && !e.ExpiresAt.Before(time.Now()) {
+			t.Fatalf("expected k to have an expiration in the past, got %v", e.ExpiresAt)
+		}
+	}
+}
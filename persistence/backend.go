@@ -0,0 +1,34 @@
+// Package persistence provides pluggable storage backends for
+// datastructures.StringStore: an append-only write-ahead log (AOF) and
+// periodic binary snapshots, plus a hybrid of the two.
+package persistence
+
+import "time"
+
+// Entry is the on-disk representation of a single key, used by Snapshot
+// and Restore.
+type Entry struct {
+	Key       string
+	Value     string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// Backend is the persistence contract a StringStore writes through. Mutating
+// operations are logged via AppendCommand; Snapshot periodically captures
+// the full dataset; Restore reconstructs it on startup.
+type Backend interface {
+	// AppendCommand logs one mutating command, e.g. ("SET", "k", "v", "0"),
+	// where the last argument is the absolute Unix expiration (0 for none).
+	AppendCommand(args ...string) error
+
+	// Snapshot persists the full current dataset. For log-based backends
+	// this doubles as a compaction (the AOF equivalent of BGREWRITEAOF).
+	Snapshot(entries []Entry) error
+
+	// Restore reconstructs the dataset this backend last persisted.
+	Restore() ([]Entry, error)
+
+	// Close releases any open files or resources.
+	Close() error
+}
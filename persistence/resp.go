@@ -0,0 +1,72 @@
+package persistence
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// encodeCommand RESP-encodes args as an array of bulk strings, the same
+// wire format the server package speaks, e.g.
+// encodeCommand("SET", "k", "v") -> "*3\r\n$3\r\nSET\r\n$1\r\nk\r\n$1\r\nv\r\n"
+func encodeCommand(args ...string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	return []byte(b.String())
+}
+
+// decodeCommand reads one RESP array-of-bulk-strings command from r
+func decodeCommand(r *bufio.Reader) ([]string, error) {
+	header, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	header = strings.TrimRight(header, "\r\n")
+	if len(header) == 0 || header[0] != '*' {
+		return nil, fmt.Errorf("persistence: malformed AOF record %q", header)
+	}
+
+	count, err := strconv.Atoi(header[1:])
+	if err != nil {
+		return nil, fmt.Errorf("persistence: malformed AOF array length %q", header)
+	}
+
+	args := make([]string, count)
+	for i := 0; i < count; i++ {
+		lenLine, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		lenLine = strings.TrimRight(lenLine, "\r\n")
+		if len(lenLine) == 0 || lenLine[0] != '$' {
+			return nil, fmt.Errorf("persistence: malformed AOF bulk header %q", lenLine)
+		}
+		length, err := strconv.Atoi(lenLine[1:])
+		if err != nil {
+			return nil, fmt.Errorf("persistence: malformed AOF bulk length %q", lenLine)
+		}
+
+		buf := make([]byte, length+2)
+		if _, err := readFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:length])
+	}
+	return args, nil
+}
+
+func readFull(r *bufio.Reader, buf []byte) (int, error) {
+	total := 0
+	for total < len(buf) {
+		n, err := r.Read(buf[total:])
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,402 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	datastructures "github.com/avaniausekar/redis-toy-implementation/datastructure"
+)
+
+// dispatcher maps RESP commands onto StringStore method calls
+type dispatcher struct {
+	store *datastructures.StringStore
+}
+
+func newDispatcher(store *datastructures.StringStore) *dispatcher {
+	return &dispatcher{store: store}
+}
+
+// dispatch executes a single command, given as its name (matched
+// case-insensitively) followed by its arguments, and returns the RESP reply
+func (d *dispatcher) dispatch(args []string) Value {
+	if len(args) == 0 {
+		return Error("ERR empty command")
+	}
+
+	name := strings.ToUpper(args[0])
+	args = args[1:]
+
+	switch name {
+	case "PING":
+		if len(args) == 0 {
+			return SimpleString("PONG")
+		}
+		return BulkString(args[0])
+	case "GET":
+		return d.get(args)
+	case "SET":
+		return d.set(args)
+	case "DEL":
+		return d.del(args)
+	case "EXISTS":
+		return d.exists(args)
+	case "INCR":
+		return d.incrBy(args, 1)
+	case "INCRBY":
+		return d.incrByArg(args, 1)
+	case "DECR":
+		return d.incrBy(args, -1)
+	case "DECRBY":
+		return d.incrByArg(args, -1)
+	case "KEYS":
+		return d.keys(args)
+	case "SCAN":
+		return d.scan(args)
+	case "APPEND":
+		return d.append(args)
+	case "STRLEN":
+		return d.strlen(args)
+	case "GETRANGE":
+		return d.getrange(args)
+	case "SETRANGE":
+		return d.setrange(args)
+	case "MGET":
+		return d.mget(args)
+	case "MSET":
+		return d.mset(args)
+	case "TTL":
+		return d.ttl(args)
+	case "EXPIRE":
+		return d.expire(args)
+	case "PERSIST":
+		return d.persist(args)
+	default:
+		return Error(fmt.Sprintf("ERR unknown command '%s'", name))
+	}
+}
+
+func (d *dispatcher) get(args []string) Value {
+	if len(args) != 1 {
+		return wrongArgs("get")
+	}
+	value, ok := d.store.Get(args[0])
+	if !ok {
+		return NullBulkString()
+	}
+	return BulkString(value)
+}
+
+// setOptions holds the parsed form of SET's optional arguments
+type setOptions struct {
+	expiration time.Duration
+	hasExpiry  bool
+	// expireImmediately is set when EXAT/PXAT resolves to a time that has
+	// already passed: the key must be written and then treated as expired,
+	// not given no expiration at all.
+	expireImmediately bool
+	nx                bool
+	xx                bool
+	keepTTL           bool
+}
+
+func (d *dispatcher) set(args []string) Value {
+	if len(args) < 2 {
+		return wrongArgs("set")
+	}
+	key, value := args[0], args[1]
+
+	opts, err := parseSetOptions(args[2:])
+	if err != nil {
+		return Error(err.Error())
+	}
+
+	storeOpts := datastructures.SetOptions{KeepTTL: opts.keepTTL, NX: opts.nx, XX: opts.xx}
+	if opts.hasExpiry {
+		storeOpts.Expiration = opts.expiration
+		storeOpts.ExpireImmediately = opts.expireImmediately
+	}
+
+	ok, err := d.store.Set(key, value, storeOpts)
+	if err != nil {
+		return Error(fmt.Sprintf("ERR %s", err))
+	}
+	if !ok {
+		return NullBulkString()
+	}
+	return SimpleString("OK")
+}
+
+func parseSetOptions(args []string) (setOptions, error) {
+	var opts setOptions
+
+	for i := 0; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "NX":
+			opts.nx = true
+		case "XX":
+			opts.xx = true
+		case "KEEPTTL":
+			opts.keepTTL = true
+		case "EX", "PX":
+			unit := strings.ToUpper(args[i])
+			i++
+			if i >= len(args) {
+				return opts, fmt.Errorf("ERR syntax error")
+			}
+			n, err := strconv.ParseInt(args[i], 10, 64)
+			if err != nil {
+				return opts, fmt.Errorf("ERR value is not an integer or out of range")
+			}
+			if n <= 0 {
+				return opts, fmt.Errorf("ERR invalid expire time in 'set' command")
+			}
+			if unit == "EX" {
+				opts.expiration = time.Duration(n) * time.Second
+			} else {
+				opts.expiration = time.Duration(n) * time.Millisecond
+			}
+			opts.hasExpiry = true
+		case "EXAT", "PXAT":
+			unit := strings.ToUpper(args[i])
+			i++
+			if i >= len(args) {
+				return opts, fmt.Errorf("ERR syntax error")
+			}
+			n, err := strconv.ParseInt(args[i], 10, 64)
+			if err != nil {
+				return opts, fmt.Errorf("ERR value is not an integer or out of range")
+			}
+			var at time.Time
+			if unit == "EXAT" {
+				at = time.Unix(n, 0)
+			} else {
+				at = time.UnixMilli(n)
+			}
+			if until := time.Until(at); until > 0 {
+				opts.expiration = until
+			} else {
+				opts.expireImmediately = true
+			}
+			opts.hasExpiry = true
+		default:
+			return opts, fmt.Errorf("ERR syntax error")
+		}
+	}
+
+	if opts.nx && opts.xx {
+		return opts, fmt.Errorf("ERR syntax error")
+	}
+
+	return opts, nil
+}
+
+func (d *dispatcher) del(args []string) Value {
+	if len(args) == 0 {
+		return wrongArgs("del")
+	}
+	deleted := 0
+	for _, key := range args {
+		if d.store.Delete(key) {
+			deleted++
+		}
+	}
+	return Integer(int64(deleted))
+}
+
+func (d *dispatcher) exists(args []string) Value {
+	if len(args) == 0 {
+		return wrongArgs("exists")
+	}
+	return Integer(int64(d.store.Exists(args...)))
+}
+
+func (d *dispatcher) incrBy(args []string, delta int) Value {
+	if len(args) != 1 {
+		return wrongArgs("incr/decr")
+	}
+	n, err := d.store.Increment(args[0], delta)
+	if err != nil {
+		return Error(fmt.Sprintf("ERR %s", err))
+	}
+	return Integer(int64(n))
+}
+
+func (d *dispatcher) incrByArg(args []string, sign int) Value {
+	if len(args) != 2 {
+		return wrongArgs("incrby/decrby")
+	}
+	delta, err := strconv.Atoi(args[1])
+	if err != nil {
+		return Error("ERR value is not an integer or out of range")
+	}
+	n, err := d.store.Increment(args[0], sign*delta)
+	if err != nil {
+		return Error(fmt.Sprintf("ERR %s", err))
+	}
+	return Integer(int64(n))
+}
+
+func (d *dispatcher) keys(args []string) Value {
+	if len(args) != 1 {
+		return wrongArgs("keys")
+	}
+	values := make([]Value, 0)
+	for _, key := range d.store.Keys() {
+		if matchPattern(args[0], key) {
+			values = append(values, BulkString(key))
+		}
+	}
+	return Array(values...)
+}
+
+func (d *dispatcher) scan(args []string) Value {
+	if len(args) == 0 {
+		return wrongArgs("scan")
+	}
+	cursor, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return Error("ERR invalid cursor")
+	}
+
+	count := 0
+	for i := 1; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "COUNT":
+			i++
+			if i >= len(args) {
+				return Error("ERR syntax error")
+			}
+			count, err = strconv.Atoi(args[i])
+			if err != nil {
+				return Error("ERR value is not an integer or out of range")
+			}
+		default:
+			return Error("ERR syntax error")
+		}
+	}
+
+	keys, next := d.store.Scan(cursor, count)
+	values := make([]Value, len(keys))
+	for i, key := range keys {
+		values[i] = BulkString(key)
+	}
+	return Array(BulkString(strconv.FormatUint(next, 10)), Array(values...))
+}
+
+func (d *dispatcher) append(args []string) Value {
+	if len(args) != 2 {
+		return wrongArgs("append")
+	}
+	n, err := d.store.Append(args[0], args[1])
+	if err != nil {
+		return Error(fmt.Sprintf("ERR %s", err))
+	}
+	return Integer(int64(n))
+}
+
+func (d *dispatcher) strlen(args []string) Value {
+	if len(args) != 1 {
+		return wrongArgs("strlen")
+	}
+	return Integer(int64(d.store.Strlen(args[0])))
+}
+
+func (d *dispatcher) getrange(args []string) Value {
+	if len(args) != 3 {
+		return wrongArgs("getrange")
+	}
+	start, err := strconv.Atoi(args[1])
+	if err != nil {
+		return Error("ERR value is not an integer or out of range")
+	}
+	end, err := strconv.Atoi(args[2])
+	if err != nil {
+		return Error("ERR value is not an integer or out of range")
+	}
+	return BulkString(d.store.GetRange(args[0], start, end))
+}
+
+func (d *dispatcher) setrange(args []string) Value {
+	if len(args) != 3 {
+		return wrongArgs("setrange")
+	}
+	offset, err := strconv.Atoi(args[1])
+	if err != nil {
+		return Error("ERR value is not an integer or out of range")
+	}
+	n, err := d.store.SetRange(args[0], offset, args[2])
+	if err != nil {
+		return Error(fmt.Sprintf("ERR %s", err))
+	}
+	return Integer(int64(n))
+}
+
+func (d *dispatcher) mget(args []string) Value {
+	if len(args) == 0 {
+		return wrongArgs("mget")
+	}
+	results := d.store.MGet(args...)
+	values := make([]Value, len(results))
+	for i, r := range results {
+		if r == nil {
+			values[i] = NullBulkString()
+		} else {
+			values[i] = BulkString(*r)
+		}
+	}
+	return Array(values...)
+}
+
+func (d *dispatcher) mset(args []string) Value {
+	if len(args) == 0 || len(args)%2 != 0 {
+		return Error("ERR wrong number of arguments for 'mset' command")
+	}
+	if err := d.store.MSet(args...); err != nil {
+		return Error(fmt.Sprintf("ERR %s", err))
+	}
+	return SimpleString("OK")
+}
+
+func (d *dispatcher) ttl(args []string) Value {
+	if len(args) != 1 {
+		return wrongArgs("ttl")
+	}
+	remaining, exists := d.store.TTL(args[0])
+	if !exists {
+		return Integer(-2)
+	}
+	if remaining < 0 {
+		return Integer(-1)
+	}
+	return Integer(int64(remaining / time.Second))
+}
+
+func (d *dispatcher) expire(args []string) Value {
+	if len(args) != 2 {
+		return wrongArgs("expire")
+	}
+	seconds, err := strconv.Atoi(args[1])
+	if err != nil {
+		return Error("ERR value is not an integer or out of range")
+	}
+	if !d.store.Expire(args[0], time.Duration(seconds)*time.Second) {
+		return Integer(0)
+	}
+	return Integer(1)
+}
+
+func (d *dispatcher) persist(args []string) Value {
+	if len(args) != 1 {
+		return wrongArgs("persist")
+	}
+	if !d.store.Persist(args[0]) {
+		return Integer(0)
+	}
+	return Integer(1)
+}
+
+func wrongArgs(command string) Value {
+	return Error(fmt.Sprintf("ERR wrong number of arguments for '%s' command", command))
+}
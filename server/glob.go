@@ -0,0 +1,80 @@
+package server
+
+// matchPattern reports whether key matches a Redis-style glob pattern,
+// supporting '*', '?' and character classes like '[abc]' or '[^abc]'.
+func matchPattern(pattern, key string) bool {
+	return globMatch(pattern, key)
+}
+
+func globMatch(pattern, s string) bool {
+	if pattern == "" {
+		return s == ""
+	}
+
+	switch pattern[0] {
+	case '*':
+		if globMatch(pattern[1:], s) {
+			return true
+		}
+		for i := 0; i < len(s); i++ {
+			if globMatch(pattern[1:], s[i+1:]) {
+				return true
+			}
+		}
+		return false
+	case '?':
+		if len(s) == 0 {
+			return false
+		}
+		return globMatch(pattern[1:], s[1:])
+	case '[':
+		if len(s) == 0 {
+			return false
+		}
+		end := indexByte(pattern, ']')
+		if end < 0 {
+			// no closing bracket, treat '[' literally
+			return s[0] == '[' && globMatch(pattern[1:], s[1:])
+		}
+		class := pattern[1:end]
+		negate := false
+		if len(class) > 0 && class[0] == '^' {
+			negate = true
+			class = class[1:]
+		}
+		if classMatches(class, s[0]) != negate {
+			return globMatch(pattern[end+1:], s[1:])
+		}
+		return false
+	default:
+		if len(s) == 0 || s[0] != pattern[0] {
+			return false
+		}
+		return globMatch(pattern[1:], s[1:])
+	}
+}
+
+func classMatches(class string, b byte) bool {
+	for i := 0; i < len(class); i++ {
+		if i+2 < len(class) && class[i+1] == '-' {
+			if class[i] <= b && b <= class[i+2] {
+				return true
+			}
+			i += 2
+			continue
+		}
+		if class[i] == b {
+			return true
+		}
+	}
+	return false
+}
+
+func indexByte(s string, b byte) int {
+	for i := 0; i < len(s); i++ {
+		if s[i] == b {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,217 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// kind identifies which RESP type a Value holds
+type kind int
+
+const (
+	kindSimpleString kind = iota
+	kindError
+	kindInteger
+	kindBulkString
+	kindNullBulkString
+	kindArray
+	kindNullArray
+)
+
+// Value is a parsed or to-be-encoded RESP value. RESP3 clients are served the
+// same wire types as RESP2 (bulk strings, arrays, nulls) since none of the
+// commands implemented here need maps, sets, doubles or booleans yet.
+type Value struct {
+	kind  kind
+	str   string
+	num   int64
+	array []Value
+}
+
+// SimpleString builds a RESP simple string, e.g. +OK\r\n
+func SimpleString(s string) Value { return Value{kind: kindSimpleString, str: s} }
+
+// Error builds a RESP error, e.g. -ERR message\r\n
+func Error(s string) Value { return Value{kind: kindError, str: s} }
+
+// Integer builds a RESP integer, e.g. :123\r\n
+func Integer(n int64) Value { return Value{kind: kindInteger, num: n} }
+
+// BulkString builds a RESP bulk string
+func BulkString(s string) Value { return Value{kind: kindBulkString, str: s} }
+
+// NullBulkString builds the RESP nil bulk string, $-1\r\n
+func NullBulkString() Value { return Value{kind: kindNullBulkString} }
+
+// Array builds a RESP array from the given elements
+func Array(values ...Value) Value { return Value{kind: kindArray, array: values} }
+
+// NullArray builds the RESP nil array, *-1\r\n
+func NullArray() Value { return Value{kind: kindNullArray} }
+
+// IsError reports whether v is a RESP error value
+func (v Value) IsError() bool { return v.kind == kindError }
+
+// readValue reads one RESP value from r. It also accepts the inline command
+// format used by plain-text clients such as telnet: a line of
+// whitespace-separated arguments terminated by \r\n or \n, with no leading
+// type byte.
+func readValue(r *bufio.Reader) (Value, error) {
+	b, err := r.Peek(1)
+	if err != nil {
+		return Value{}, err
+	}
+
+	switch b[0] {
+	case '*':
+		return readArray(r)
+	case '$':
+		return readBulkString(r)
+	case '+':
+		line, err := readLine(r)
+		if err != nil {
+			return Value{}, err
+		}
+		return SimpleString(line[1:]), nil
+	case '-':
+		line, err := readLine(r)
+		if err != nil {
+			return Value{}, err
+		}
+		return Error(line[1:]), nil
+	case ':':
+		line, err := readLine(r)
+		if err != nil {
+			return Value{}, err
+		}
+		n, err := strconv.ParseInt(line[1:], 10, 64)
+		if err != nil {
+			return Value{}, fmt.Errorf("invalid integer: %q", line)
+		}
+		return Integer(n), nil
+	default:
+		return readInline(r)
+	}
+}
+
+// readInline parses a command given as plain whitespace-separated text
+func readInline(r *bufio.Reader) (Value, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return Value{}, err
+	}
+	fields := strings.Fields(line)
+	values := make([]Value, len(fields))
+	for i, f := range fields {
+		values[i] = BulkString(f)
+	}
+	return Array(values...), nil
+}
+
+// readArray parses a RESP array, e.g. *2\r\n$3\r\nGET\r\n$3\r\nfoo\r\n
+func readArray(r *bufio.Reader) (Value, error) {
+	header, err := readLine(r)
+	if err != nil {
+		return Value{}, err
+	}
+	count, err := strconv.Atoi(header[1:])
+	if err != nil {
+		return Value{}, fmt.Errorf("invalid array length: %q", header)
+	}
+	if count < 0 {
+		return NullArray(), nil
+	}
+
+	values := make([]Value, count)
+	for i := 0; i < count; i++ {
+		v, err := readValue(r)
+		if err != nil {
+			return Value{}, err
+		}
+		values[i] = v
+	}
+	return Array(values...), nil
+}
+
+// readBulkString parses a RESP bulk string, e.g. $3\r\nfoo\r\n
+func readBulkString(r *bufio.Reader) (Value, error) {
+	header, err := readLine(r)
+	if err != nil {
+		return Value{}, err
+	}
+	length, err := strconv.Atoi(header[1:])
+	if err != nil {
+		return Value{}, fmt.Errorf("invalid bulk string length: %q", header)
+	}
+	if length < 0 {
+		return NullBulkString(), nil
+	}
+
+	buf := make([]byte, length+2) // +2 for the trailing \r\n
+	if _, err := readFull(r, buf); err != nil {
+		return Value{}, err
+	}
+	return BulkString(string(buf[:length])), nil
+}
+
+// readLine reads up to and including \r\n (or a bare \n), returning the
+// content without the terminator
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	return line, nil
+}
+
+// readFull fills buf completely from r
+func readFull(r *bufio.Reader, buf []byte) (int, error) {
+	total := 0
+	for total < len(buf) {
+		n, err := r.Read(buf[total:])
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// writeValue encodes v onto w using the RESP wire format
+func writeValue(w *bufio.Writer, v Value) error {
+	switch v.kind {
+	case kindSimpleString:
+		_, err := fmt.Fprintf(w, "+%s\r\n", v.str)
+		return err
+	case kindError:
+		_, err := fmt.Fprintf(w, "-%s\r\n", v.str)
+		return err
+	case kindInteger:
+		_, err := fmt.Fprintf(w, ":%d\r\n", v.num)
+		return err
+	case kindBulkString:
+		_, err := fmt.Fprintf(w, "$%d\r\n%s\r\n", len(v.str), v.str)
+		return err
+	case kindNullBulkString:
+		_, err := w.WriteString("$-1\r\n")
+		return err
+	case kindNullArray:
+		_, err := w.WriteString("*-1\r\n")
+		return err
+	case kindArray:
+		if _, err := fmt.Fprintf(w, "*%d\r\n", len(v.array)); err != nil {
+			return err
+		}
+		for _, elem := range v.array {
+			if err := writeValue(w, elem); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("resp: unknown value kind %d", v.kind)
+	}
+}
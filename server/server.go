@@ -0,0 +1,111 @@
+// Package server exposes a datastructures.StringStore over TCP using the
+// Redis serialization protocol, so standard clients such as redis-cli or
+// go-redis can talk to it directly.
+package server
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"log"
+	"net"
+
+	datastructures "github.com/avaniausekar/redis-toy-implementation/datastructure"
+)
+
+// Server accepts RESP connections and dispatches commands against a
+// StringStore
+type Server struct {
+	addr     string
+	store    *datastructures.StringStore
+	listener net.Listener
+}
+
+// New creates a Server that will serve store over addr (e.g. ":6379")
+func New(addr string, store *datastructures.StringStore) *Server {
+	return &Server{addr: addr, store: store}
+}
+
+// ListenAndServe binds addr and serves connections until Close is called
+func (s *Server) ListenAndServe() error {
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	s.listener = listener
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go s.handleConn(conn)
+	}
+}
+
+// Close stops the server from accepting new connections
+func (s *Server) Close() error {
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Close()
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	writer := bufio.NewWriter(conn)
+	dispatcher := newDispatcher(s.store)
+
+	for {
+		request, err := readValue(reader)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				log.Printf("server: read error from %s: %v", conn.RemoteAddr(), err)
+			}
+			return
+		}
+
+		args, err := toArgs(request)
+		if err != nil {
+			writeValue(writer, Error("ERR "+err.Error()))
+			writer.Flush()
+			continue
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		reply := dispatcher.dispatch(args)
+		if err := writeValue(writer, reply); err != nil {
+			log.Printf("server: write error to %s: %v", conn.RemoteAddr(), err)
+			return
+		}
+		if err := writer.Flush(); err != nil {
+			log.Printf("server: flush error to %s: %v", conn.RemoteAddr(), err)
+			return
+		}
+	}
+}
+
+// toArgs converts a parsed request into its command name and arguments.
+// Commands always arrive as either an inline line or an array of bulk
+// strings.
+func toArgs(v Value) ([]string, error) {
+	if v.kind != kindArray {
+		return nil, errors.New("expected command array")
+	}
+
+	args := make([]string, len(v.array))
+	for i, elem := range v.array {
+		if elem.kind != kindBulkString {
+			return nil, errors.New("expected bulk string command argument")
+		}
+		args[i] = elem.str
+	}
+	return args, nil
+}